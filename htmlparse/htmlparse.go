@@ -0,0 +1,95 @@
+// Package htmlparse holds the HTML parameter extraction pipeline shared by
+// arjunx's active crawler and its passive proxy capture mode, so both see
+// parameters the same way regardless of how the HTML reached them.
+package htmlparse
+
+import (
+    "log"
+    "net/url"
+    "strings"
+
+    "github.com/PuerkitoBio/goquery"
+)
+
+// ExtractQueryParams walks an HTML document for <a>/<form>/<input>/<select>/
+// <textarea> tags, returning every parameter name it finds together with a
+// source tag recording how it was found: "html_input" for a name attribute
+// on any of those tags, "html_form_action" for a query key parsed out of an
+// href or action attribute.
+func ExtractQueryParams(responseBody string) (url.Values, map[string][]string) {
+    queryParameters := make(url.Values)
+    sources := make(map[string][]string)
+
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(responseBody))
+    if err != nil {
+        log.Printf("Error creating document from HTML: %v", err)
+        return queryParameters, sources
+    }
+
+    doc.Find("a, form, input, select, textarea").Each(func(i int, s *goquery.Selection) {
+        name, exists := s.Attr("name")
+        if exists {
+            value := s.AttrOr("value", "FUZZ")
+            queryParameters.Add(name, value)
+            AddSourceTag(sources, name, "html_input")
+        }
+
+        // For `<a>` and `<form>` tags, extract URLs and parse their query parameters
+        href, exists := s.Attr("href")
+        if exists {
+            ParseURLAndAddQueryParameters(href, "html_form_action", queryParameters, sources)
+        }
+
+        action, exists := s.Attr("action")
+        if exists {
+            ParseURLAndAddQueryParameters(action, "html_form_action", queryParameters, sources)
+        }
+    })
+
+    return queryParameters, sources
+}
+
+// ParseURLAndAddQueryParameters parses rawurl's query string into params,
+// tagging each discovered key with source.
+func ParseURLAndAddQueryParameters(rawurl, source string, params url.Values, sources map[string][]string) {
+    parsedURL, err := url.Parse(rawurl)
+    if err != nil {
+        log.Printf("Error parsing URL %s: %v\n", rawurl, err)
+        return
+    }
+    for key, values := range parsedURL.Query() {
+        for _, value := range values {
+            params.Add(key, value)
+        }
+        AddSourceTag(sources, key, source)
+    }
+}
+
+// AddSourceTag records that parameter name was discovered via src, without
+// duplicating an already-recorded tag.
+func AddSourceTag(sources map[string][]string, name, src string) {
+    for _, existing := range sources[name] {
+        if existing == src {
+            return
+        }
+    }
+    sources[name] = append(sources[name], src)
+}
+
+// MergeValues adds every value in src to dst in place.
+func MergeValues(dst, src url.Values) {
+    for key, values := range src {
+        for _, value := range values {
+            dst.Add(key, value)
+        }
+    }
+}
+
+// MergeSources adds every source tag in src to dst in place.
+func MergeSources(dst, src map[string][]string) {
+    for name, srcs := range src {
+        for _, s := range srcs {
+            AddSourceTag(dst, name, s)
+        }
+    }
+}
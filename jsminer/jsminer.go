@@ -0,0 +1,81 @@
+// Package jsminer pulls candidate parameter names and endpoint URLs out of
+// raw JavaScript source. It's a set of best-effort regexes, not a parser:
+// arjunx only needs likely FUZZ targets, not a correct AST.
+package jsminer
+
+import (
+    "net/url"
+    "regexp"
+)
+
+// objectLiteralKey matches string-literal keys inside object literals, e.g.
+// the `foo` and `bar` in `{foo: 1, bar: "x"}`.
+var objectLiteralKey = regexp.MustCompile(`[{,]\s*['"]?([A-Za-z_$][\w$]*)['"]?\s*:`)
+
+// paramSinkCall matches the first string-literal argument to the handful of
+// browser/XHR APIs that take a parameter name as their first argument:
+// URLSearchParams.append/set and FormData.append.
+var paramSinkCall = regexp.MustCompile(`\.(?:append|set)\(\s*['"]([^'"]+)['"]`)
+
+// axiosParams matches the object passed as `params` to an axios call, e.g.
+// `axios.get(url, {params: {foo: 1, bar: 2}})`.
+var axiosParams = regexp.MustCompile(`\bparams\s*:\s*\{([^}]*)\}`)
+
+// jqueryAjaxData matches the object passed as `data` to `$.ajax({...})`.
+var jqueryAjaxData = regexp.MustCompile(`\bdata\s*:\s*\{([^}]*)\}`)
+
+// urlLiteral matches quoted string literals that look like URLs carrying a
+// query string, e.g. "/api/search?q=test&page=1".
+var urlLiteral = regexp.MustCompile(`['"]((?:https?://|/)[^'"\s]*\?[^'"\s]+)['"]`)
+
+// Result holds everything mined out of one JS source.
+type Result struct {
+    Params    []string
+    Endpoints []string
+}
+
+// Mine scans js for parameter names and endpoint URLs using a fixed set of
+// regexes covering common patterns: object-literal keys, URLSearchParams/
+// FormData sinks, axios `params` objects, jQuery `$.ajax` `data` objects,
+// and raw URL literals with a query string, whose query keys are also
+// surfaced as parameter names.
+func Mine(js string) Result {
+    params := make(map[string]bool)
+    endpoints := make(map[string]bool)
+
+    addParams := func(matches [][]string) {
+        for _, m := range matches {
+            if len(m) > 1 && m[1] != "" {
+                params[m[1]] = true
+            }
+        }
+    }
+
+    addParams(objectLiteralKey.FindAllStringSubmatch(js, -1))
+    addParams(paramSinkCall.FindAllStringSubmatch(js, -1))
+
+    for _, block := range axiosParams.FindAllStringSubmatch(js, -1) {
+        addParams(objectLiteralKey.FindAllStringSubmatch("{"+block[1]+"}", -1))
+    }
+    for _, block := range jqueryAjaxData.FindAllStringSubmatch(js, -1) {
+        addParams(objectLiteralKey.FindAllStringSubmatch("{"+block[1]+"}", -1))
+    }
+
+    for _, m := range urlLiteral.FindAllStringSubmatch(js, -1) {
+        endpoints[m[1]] = true
+        if u, err := url.Parse(m[1]); err == nil {
+            for key := range u.Query() {
+                params[key] = true
+            }
+        }
+    }
+
+    res := Result{}
+    for p := range params {
+        res.Params = append(res.Params, p)
+    }
+    for e := range endpoints {
+        res.Endpoints = append(res.Endpoints, e)
+    }
+    return res
+}
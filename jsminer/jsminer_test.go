@@ -0,0 +1,88 @@
+package jsminer
+
+import (
+    "sort"
+    "testing"
+)
+
+func sortedStrings(ss []string) []string {
+    out := append([]string(nil), ss...)
+    sort.Strings(out)
+    return out
+}
+
+func equalStrings(a, b []string) bool {
+    a, b = sortedStrings(a), sortedStrings(b)
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func TestMine(t *testing.T) {
+    tests := []struct {
+        name          string
+        js            string
+        wantParams    []string
+        wantEndpoints []string
+    }{
+        {
+            name:       "object literal keys",
+            js:         `const payload = {foo: 1, bar: "x"};`,
+            wantParams: []string{"foo", "bar"},
+        },
+        {
+            name:       "URLSearchParams append and set",
+            js:         `params.append("token", t); params.set('redirect', r);`,
+            wantParams: []string{"token", "redirect"},
+        },
+        {
+            name:       "FormData append",
+            js:         `const fd = new FormData(); fd.append("file", blob);`,
+            wantParams: []string{"file"},
+        },
+        {
+            name:       "axios params object",
+            js:         `axios.get(url, {params: {search: q, page: p}});`,
+            wantParams: []string{"params", "search", "page"},
+        },
+        {
+            name:       "jquery ajax data object",
+            js:         `$.ajax({url: "/api", data: {id: id, debug: true}});`,
+            wantParams: []string{"url", "data", "id", "debug"},
+        },
+        {
+            name:          "raw URL literal with query string",
+            js:            `fetch("/api/search?q=test&page=1")`,
+            wantParams:    []string{"q", "page"},
+            wantEndpoints: []string{"/api/search?q=test&page=1"},
+        },
+        {
+            name:          "absolute URL literal",
+            js:            `const u = 'https://api.example.com/v1/items?limit=10';`,
+            wantParams:    []string{"limit"},
+            wantEndpoints: []string{"https://api.example.com/v1/items?limit=10"},
+        },
+        {
+            name: "no matches",
+            js:   `console.log("hello world");`,
+        },
+    }
+
+    for _, tc := range tests {
+        t.Run(tc.name, func(t *testing.T) {
+            result := Mine(tc.js)
+            if !equalStrings(result.Params, tc.wantParams) {
+                t.Errorf("Params = %v, want %v", result.Params, tc.wantParams)
+            }
+            if !equalStrings(result.Endpoints, tc.wantEndpoints) {
+                t.Errorf("Endpoints = %v, want %v", result.Endpoints, tc.wantEndpoints)
+            }
+        })
+    }
+}
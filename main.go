@@ -3,6 +3,9 @@ package main
 import (
     "bufio"
     "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
     "flag"
     "fmt"
     "io"
@@ -10,17 +13,26 @@ import (
     "net/http"
     "net/url"
     "os"
+    "regexp"
+    "sort"
     "strings"
     "sync"
+    "sync/atomic"
     "time"
 
     "github.com/PuerkitoBio/goquery"
+    "github.com/djallalzoldik/arjunx/htmlparse"
+    "github.com/djallalzoldik/arjunx/jsminer"
+    "github.com/djallalzoldik/arjunx/output"
+    "github.com/djallalzoldik/arjunx/proxy"
+    "github.com/djallalzoldik/arjunx/renderer"
 )
 
 var (
     customHeadersFlag headerFlag
     proxyFlag         string
     outputFileFlag    string
+    outputFormatFlag  string
     concurrencyFlag   int
     timeoutFlag       time.Duration
     quietFlag         bool
@@ -30,12 +42,23 @@ var (
     baseURLFlag       string
     inputFlag         string
     errorLogFlag      string
+    renderFlag        bool
+    renderIdleFlag    time.Duration
+    jsFlag            bool
+    jsExternalFlag    bool
+    depthFlag         int
+    scopeFlag         string
+    scopeRegexFlag    string
+    probeFlag         bool
+    probeLenDeltaFlag int
+    jsonOutputFlag    string
 )
 
 func init() {
     flag.Var(&customHeadersFlag, "H", "Custom header in the form 'key: value'")
     flag.StringVar(&proxyFlag, "proxy", "", "HTTP proxy in the form 'http://127.0.0.1:8080'")
     flag.StringVar(&outputFileFlag, "o", "extracted_params.txt", "Output file for extracted parameters")
+    flag.StringVar(&outputFormatFlag, "of", "txt", "Output format: txt, json, jsonl, burp, or har")
     flag.IntVar(&concurrencyFlag, "c", 10, "Number of concurrent workers")
     flag.DurationVar(&timeoutFlag, "t", 30*time.Second, "HTTP request timeout")
     flag.BoolVar(&quietFlag, "q", false, "Quiet mode (suppress non-error output)")
@@ -45,6 +68,16 @@ func init() {
     flag.StringVar(&baseURLFlag, "baseurl", "", "Base URL to prepend to input URLs")
     flag.StringVar(&inputFlag, "i", "", "Input file containing URLs to process")
     flag.StringVar(&errorLogFlag, "e", "", "Error log file")
+    flag.BoolVar(&renderFlag, "render", false, "Render pages in headless Chromium before extracting parameters")
+    flag.DurationVar(&renderIdleFlag, "idle", 500*time.Millisecond, "Network-idle quiet period to wait for when -render is set")
+    flag.BoolVar(&jsFlag, "js", false, "Mine linked and inline <script> source for parameters and endpoints")
+    flag.BoolVar(&jsExternalFlag, "js-external", false, "When -js is set, also mine scripts hosted on other origins")
+    flag.IntVar(&depthFlag, "depth", 0, "Crawl depth: follow in-scope links this many hops from each input URL (0 disables crawling)")
+    flag.StringVar(&scopeFlag, "scope", "host", "Crawl scope: host, subdomain, or regex")
+    flag.StringVar(&scopeRegexFlag, "scope-regex", "", "Regex discovered URLs must match when -scope=regex")
+    flag.BoolVar(&probeFlag, "probe", false, "Probe each discovered parameter with a canary value and classify its behavior")
+    flag.IntVar(&probeLenDeltaFlag, "probe-len-delta", 32, "Byte delta from the baseline response length that counts as a len_delta signal")
+    flag.StringVar(&jsonOutputFlag, "json", "", "JSON lines output file for -probe results")
 }
 
 type headerFlag []string
@@ -58,14 +91,84 @@ func (h *headerFlag) Set(value string) error {
     return nil
 }
 
+// workItem is one crawl target: a URL together with how many hops it is from
+// the original input, so the worker pool can stop following links once
+// depthFlag is exceeded.
+type workItem struct {
+    url   string
+    depth int
+}
+
+// crawlQueue is an unbounded, concurrency-safe FIFO that lets workers both
+// consume and produce work items. It replaces the original one-shot
+// `chan string`, which had no way for a worker to feed newly discovered
+// links back in. inFlight tracks queued-or-in-progress items; when it drops
+// to zero the queue is drained and closes itself.
+type crawlQueue struct {
+    mu       sync.Mutex
+    cond     *sync.Cond
+    items    []workItem
+    closed   bool
+    inFlight int64
+}
+
+func newCrawlQueue() *crawlQueue {
+    q := &crawlQueue{}
+    q.cond = sync.NewCond(&q.mu)
+    return q
+}
+
+// push enqueues an item and marks it in-flight. Call before the item has
+// been accounted for by any previous pop.
+func (q *crawlQueue) push(item workItem) {
+    atomic.AddInt64(&q.inFlight, 1)
+    q.mu.Lock()
+    q.items = append(q.items, item)
+    q.cond.Signal()
+    q.mu.Unlock()
+}
+
+// pop blocks until an item is available or the queue has been closed.
+func (q *crawlQueue) pop() (workItem, bool) {
+    q.mu.Lock()
+    defer q.mu.Unlock()
+    for len(q.items) == 0 && !q.closed {
+        q.cond.Wait()
+    }
+    if len(q.items) == 0 {
+        return workItem{}, false
+    }
+    item := q.items[0]
+    q.items = q.items[1:]
+    return item, true
+}
+
+// done marks one previously-pushed item as finished. Once no items remain
+// in-flight anywhere (queued or being worked on), the queue closes and
+// wakes any workers still blocked in pop.
+func (q *crawlQueue) done() {
+    if atomic.AddInt64(&q.inFlight, -1) == 0 {
+        q.mu.Lock()
+        q.closed = true
+        q.cond.Broadcast()
+        q.mu.Unlock()
+    }
+}
+
 func main() {
+    if len(os.Args) > 1 && os.Args[1] == "proxy" {
+        if err := proxy.Run(os.Args[2:]); err != nil {
+            log.Fatalf("proxy: %v", err)
+        }
+        return
+    }
+
     flag.Parse()
 
-    file, err := os.Create(outputFileFlag)
+    sink, err := output.New(outputFormatFlag, outputFileFlag)
     if err != nil {
-        log.Fatalf("Error creating output file: %v", err)
+        log.Fatalf("Error creating output sink: %v", err)
     }
-    defer file.Close()
 
     var errorLog *log.Logger
     if errorLogFlag != "" {
@@ -79,21 +182,34 @@ func main() {
         errorLog = log.New(os.Stderr, "ERROR: ", log.LstdFlags)
     }
 
-    urls := make(chan string, concurrencyFlag)
-    var wg sync.WaitGroup
+    var scopeRegex *regexp.Regexp
+    if scopeFlag == "regex" {
+        if scopeRegexFlag == "" {
+            log.Fatalf("-scope=regex requires -scope-regex")
+        }
+        scopeRegex, err = regexp.Compile(scopeRegexFlag)
+        if err != nil {
+            log.Fatalf("Error compiling -scope-regex: %v", err)
+        }
+    }
 
-    for i := 0; i < concurrencyFlag; i++ {
-        wg.Add(1)
-        go func() {
-            defer wg.Done()
-            for u := range urls {
-                if err := processURL(u, file, customHeadersFlag, proxyFlag, timeoutFlag, quietFlag, verboseFlag, followRedirectsFlag, methodFlag, baseURLFlag, errorLog); err != nil && !quietFlag {
-                    errorLog.Println(err)
-                }
-            }
-        }()
+    if probeFlag && jsonOutputFlag == "" {
+        log.Fatalf("-probe requires -json")
     }
 
+    var jsonSinkFile *jsonSink
+    if jsonOutputFlag != "" {
+        jsonSinkFile, err = newJSONSink(jsonOutputFlag)
+        if err != nil {
+            log.Fatalf("Error creating JSON output file: %v", err)
+        }
+        defer jsonSinkFile.Close()
+    }
+
+    queue := newCrawlQueue()
+    results := &resultSet{data: make(map[string]*output.EndpointResult)}
+    var visited sync.Map
+
     var scanner *bufio.Scanner
     if inputFlag != "" {
         inputFile, err := os.Open(inputFlag)
@@ -106,23 +222,245 @@ func main() {
         scanner = bufio.NewScanner(os.Stdin)
     }
 
+    var seeds []string
     for scanner.Scan() {
-        urls <- scanner.Text()
+        seeds = append(seeds, scanner.Text())
     }
-    close(urls)
-    wg.Wait()
-
     if err := scanner.Err(); err != nil {
         log.Fatalf("Error reading input: %v", err)
     }
+    if len(seeds) == 0 {
+        return
+    }
+
+    for _, s := range seeds {
+        if key, ok := normalizeURL(s); ok {
+            if _, loaded := visited.LoadOrStore(key, true); !loaded {
+                queue.push(workItem{url: s, depth: 0})
+            }
+        }
+    }
+    if atomic.LoadInt64(&queue.inFlight) == 0 {
+        // Every seed failed to parse; nothing will ever call done(), so
+        // close the queue now instead of leaving workers blocked forever.
+        queue.mu.Lock()
+        queue.closed = true
+        queue.cond.Broadcast()
+        queue.mu.Unlock()
+    }
+
+    var wg sync.WaitGroup
+    for i := 0; i < concurrencyFlag; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for {
+                item, ok := queue.pop()
+                if !ok {
+                    return
+                }
+                links, err := processURL(item.url, item.depth, seeds, scopeFlag, scopeRegex, results, queue, &visited, jsonSinkFile, customHeadersFlag, proxyFlag, timeoutFlag, quietFlag, followRedirectsFlag, methodFlag, baseURLFlag, errorLog)
+                if err != nil && !quietFlag {
+                    errorLog.Println(err)
+                }
+                if item.depth < depthFlag {
+                    for _, link := range links {
+                        if !inScope(link, seeds, scopeFlag, scopeRegex) {
+                            continue
+                        }
+                        key, ok := normalizeURL(link)
+                        if !ok {
+                            continue
+                        }
+                        if _, loaded := visited.LoadOrStore(key, true); loaded {
+                            continue
+                        }
+                        queue.push(workItem{url: link, depth: item.depth + 1})
+                    }
+                }
+                queue.done()
+            }
+        }()
+    }
+    wg.Wait()
+
+    if verboseFlag {
+        results.printVerbose()
+    }
+    if err := results.flush(sink); err != nil {
+        log.Fatalf("Error writing output: %v", err)
+    }
+}
+
+// resultSet merges discovered query parameters per endpoint (scheme+host+path,
+// query string stripped) rather than per input URL, so the same page reached
+// through different links or by the crawler ends up as a single output line.
+// It accumulates output.EndpointResults for the whole run; flush hands the
+// merged set to the configured output.Sink once crawling is done.
+type resultSet struct {
+    mu   sync.Mutex
+    data map[string]*output.EndpointResult
+}
+
+func (r *resultSet) merge(endpoint string, params url.Values, sources map[string][]string, exchange *output.Exchange) {
+    if len(params) == 0 {
+        return
+    }
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    existing, ok := r.data[endpoint]
+    if !ok {
+        r.data[endpoint] = &output.EndpointResult{URL: endpoint, Params: params, Sources: sources, Exchange: exchange}
+        return
+    }
+    for key, values := range params {
+        for _, value := range values {
+            existing.Params.Add(key, value)
+        }
+    }
+    for name, srcs := range sources {
+        for _, s := range srcs {
+            htmlparse.AddSourceTag(existing.Sources, name, s)
+        }
+    }
+    if exchange != nil {
+        existing.Exchange = exchange
+    }
+}
+
+func (r *resultSet) printVerbose() {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for endpoint, result := range r.data {
+        fmt.Printf("Extracted parameters from URL %s:\n", endpoint)
+        for key, values := range result.Params {
+            fmt.Printf("%s: %s\n", key, strings.Join(values, ", "))
+        }
+    }
+}
+
+func (r *resultSet) flush(sink output.Sink) error {
+    r.mu.Lock()
+    endpoints := make([]string, 0, len(r.data))
+    for endpoint := range r.data {
+        endpoints = append(endpoints, endpoint)
+    }
+    sort.Strings(endpoints)
+
+    for _, endpoint := range endpoints {
+        if err := sink.Write(*r.data[endpoint]); err != nil {
+            r.mu.Unlock()
+            return err
+        }
+    }
+    r.mu.Unlock()
+    return sink.Close()
+}
+
+// normalizeURL returns the visited-set key for a URL: scheme, host, path,
+// and the sorted set of query keys (not values), so the same endpoint
+// queried with different FUZZ values or parameter order is only crawled
+// once.
+func normalizeURL(rawurl string) (string, bool) {
+    u, err := url.Parse(rawurl)
+    if err != nil {
+        return "", false
+    }
+    keys := make([]string, 0, len(u.Query()))
+    for key := range u.Query() {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return fmt.Sprintf("%s://%s%s?%s", u.Scheme, u.Host, u.Path, strings.Join(keys, "&")), true
+}
+
+// endpointKey returns the endpoint a URL's discovered parameters are merged
+// under: everything but the query string.
+func endpointKey(rawurl string) (string, bool) {
+    u, err := url.Parse(rawurl)
+    if err != nil {
+        return "", false
+    }
+    u.RawQuery = ""
+    return u.String(), true
+}
+
+// inScope reports whether link should be followed by the crawler, given the
+// original seed URLs and the configured -scope mode.
+func inScope(link string, seeds []string, scope string, scopeRegex *regexp.Regexp) bool {
+    u, err := url.Parse(link)
+    if err != nil {
+        return false
+    }
+
+    switch scope {
+    case "regex":
+        return scopeRegex != nil && scopeRegex.MatchString(link)
+    case "subdomain":
+        for _, seed := range seeds {
+            s, err := url.Parse(seed)
+            if err != nil {
+                continue
+            }
+            root := rootDomain(s.Hostname())
+            host := u.Hostname()
+            if host == root || strings.HasSuffix(host, "."+root) {
+                return true
+            }
+        }
+        return false
+    default: // "host"
+        for _, seed := range seeds {
+            s, err := url.Parse(seed)
+            if err != nil {
+                continue
+            }
+            if u.Host == s.Host {
+                return true
+            }
+        }
+        return false
+    }
+}
+
+// rootDomain trims a hostname down to its last two labels (e.g.
+// "api.staging.example.com" -> "example.com"), a good-enough approximation
+// of the registrable domain for -scope=subdomain matching.
+func rootDomain(host string) string {
+    labels := strings.Split(host, ".")
+    if len(labels) <= 2 {
+        return host
+    }
+    return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// extractLinks resolves every <a href> and <form action> in doc against
+// base, returning absolute URLs for the crawler to consider enqueuing.
+func extractLinks(doc *goquery.Document, base *url.URL) []string {
+    var links []string
+    doc.Find("a[href], form[action]").Each(func(i int, s *goquery.Selection) {
+        raw, exists := s.Attr("href")
+        if !exists {
+            raw, exists = s.Attr("action")
+        }
+        if !exists {
+            return
+        }
+        resolved, err := base.Parse(raw)
+        if err != nil {
+            return
+        }
+        links = append(links, resolved.String())
+    })
+    return links
 }
 
-func processURL(urlStr string, file *os.File, customHeaders headerFlag, proxy string, timeout time.Duration, quiet, verbose, followRedirects bool, method, baseURL string, errorLog *log.Logger) error {
+func processURL(urlStr string, depth int, seeds []string, scope string, scopeRegex *regexp.Regexp, results *resultSet, queue *crawlQueue, visited *sync.Map, jsonSinkFile *jsonSink, customHeaders headerFlag, proxy string, timeout time.Duration, quiet, followRedirects bool, method, baseURL string, errorLog *log.Logger) ([]string, error) {
     client := &http.Client{Timeout: timeout}
     if proxy != "" {
         proxyURL, err := url.Parse(proxy)
         if err != nil {
-            return fmt.Errorf("error parsing proxy URL: %v", err)
+            return nil, fmt.Errorf("error parsing proxy URL: %v", err)
         }
         client.Transport = &http.Transport{
             Proxy: http.ProxyURL(proxyURL),
@@ -142,20 +480,21 @@ func processURL(urlStr string, file *os.File, customHeaders headerFlag, proxy st
 
     req, err := http.NewRequest(method, finalURL, nil)
     if err != nil {
-        return fmt.Errorf("error creating request: %v", err)
+        return nil, fmt.Errorf("error creating request: %v", err)
     }
 
     for _, header := range customHeaders {
         parts := strings.SplitN(header, ":", 2)
         if len(parts) != 2 {
-            return fmt.Errorf("invalid header format: %s", header)
+            return nil, fmt.Errorf("invalid header format: %s", header)
         }
         req.Header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
     }
 
+    requestStartedAt := time.Now()
     resp, err := client.Do(req)
     if err != nil {
-        return fmt.Errorf("error making request to %s: %v", urlStr, err)
+        return nil, fmt.Errorf("error making request to %s: %v", urlStr, err)
     }
     defer resp.Body.Close()
 
@@ -164,76 +503,137 @@ func processURL(urlStr string, file *os.File, customHeaders headerFlag, proxy st
         if !quiet {
             errorLog.Printf("Error reading response body from URL %s: %v\n", urlStr, err)
         }
-        return err
+        return nil, err
     }
+    pageHTML := buf.String()
+    bodySHA256 := sha256.Sum256(buf.Bytes())
 
-    queryParameters := extractQueryParamsFromHTML(buf.String())
+    queryParameters, sources := htmlparse.ExtractQueryParams(pageHTML)
 
-    if len(queryParameters) > 0 {
-        parsedURL, err := url.Parse(urlStr)
-        if err != nil {
-            return fmt.Errorf("error parsing URL %s: %v", urlStr, err)
-        }
-        parsedURL.RawQuery = queryParameters.Encode()
+    if jsFlag {
+        mineScripts(client, finalURL, pageHTML, queryParameters, sources, depth, seeds, scope, scopeRegex, queue, visited, quiet, errorLog)
+    }
 
-        modifiedURL := parsedURL.String()
-        if _, err := file.WriteString(modifiedURL + "\n"); err != nil {
+    if renderFlag {
+        rendered, err := renderer.Render(context.Background(), finalURL, timeout, renderIdleFlag)
+        if err != nil {
             if !quiet {
-                errorLog.Printf("Error writing to file: %v\n", err)
+                errorLog.Printf("Error rendering URL %s: %v\n", urlStr, err)
             }
-            return err
-        }
-
-        if verbose {
-            fmt.Printf("Extracted parameters from URL %s:\n", urlStr)
-            for key, values := range queryParameters {
-                fmt.Printf("%s: %s\n", key, strings.Join(values, ", "))
+        } else {
+            renderedParams, renderedSources := htmlparse.ExtractQueryParams(rendered.HTML)
+            htmlparse.MergeValues(queryParameters, renderedParams)
+            htmlparse.MergeSources(sources, renderedSources)
+            for _, reqURL := range rendered.RequestURLs {
+                htmlparse.ParseURLAndAddQueryParameters(reqURL, "xhr", queryParameters, sources)
             }
         }
     }
-    return nil
-}
-
-func extractQueryParamsFromHTML(responseBody string) url.Values {
-    queryParameters := make(url.Values)
 
-    doc, err := goquery.NewDocumentFromReader(strings.NewReader(responseBody))
-    if err != nil {
-        log.Printf("Error creating document from HTML: %v", err)
-        return queryParameters
+    exchange := &output.Exchange{
+        Method:          method,
+        Status:          resp.StatusCode,
+        RequestHeaders:  req.Header,
+        ResponseHeaders: resp.Header,
+        StartedAt:       requestStartedAt,
+        Duration:        time.Since(requestStartedAt),
+        BodySize:        buf.Len(),
+        BodySHA256:      hex.EncodeToString(bodySHA256[:]),
     }
 
-    doc.Find("a, form, input, select, textarea").Each(func(i int, s *goquery.Selection) {
-        name, exists := s.Attr("name")
-        if exists {
-            value := s.AttrOr("value", "FUZZ")
-            queryParameters.Add(name, value)
-        }
+    if endpoint, ok := endpointKey(urlStr); ok {
+        results.merge(endpoint, queryParameters, sources, exchange)
+    }
 
-        // For `<a>` and `<form>` tags, extract URLs and parse their query parameters
-        href, exists := s.Attr("href")
-        if exists {
-            parseURLAndAddQueryParameters(href, queryParameters)
+    if probeFlag && jsonSinkFile != nil && len(queryParameters) > 0 {
+        for _, signal := range probeParams(client, method, finalURL, customHeaders, queryParameters, probeLenDeltaFlag, errorLog, quiet) {
+            if err := jsonSinkFile.write(signal); err != nil && !quiet {
+                errorLog.Printf("Error writing probe result: %v\n", err)
+            }
         }
+    }
 
-        action, exists := s.Attr("action")
-        if exists {
-            parseURLAndAddQueryParameters(action, queryParameters)
+    var links []string
+    if doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageHTML)); err == nil {
+        if base, err := url.Parse(finalURL); err == nil {
+            links = extractLinks(doc, base)
         }
-    })
+    }
 
-    return queryParameters
+    return links, nil
 }
 
-func parseURLAndAddQueryParameters(rawurl string, params url.Values) {
-    parsedURL, err := url.Parse(rawurl)
+// mineScripts walks every <script> tag in pageHTML, mining its source (fetched
+// for src= tags, taken inline otherwise) for parameter names and endpoint
+// URLs via jsminer. Discovered parameters are added to queryParameters with
+// value FUZZ; discovered endpoints are pushed back onto the crawl queue one
+// hop deeper than depth, subject to the same inScope and depthFlag gates
+// that govern links found in HTML. External scripts are skipped unless
+// -js-external is set.
+func mineScripts(client *http.Client, pageURL, pageHTML string, queryParameters url.Values, sources map[string][]string, depth int, seeds []string, scope string, scopeRegex *regexp.Regexp, queue *crawlQueue, visited *sync.Map, quiet bool, errorLog *log.Logger) {
+    doc, err := goquery.NewDocumentFromReader(strings.NewReader(pageHTML))
     if err != nil {
-        log.Printf("Error parsing URL %s: %v\n", rawurl, err)
         return
     }
-    for key, values := range parsedURL.Query() {
-        for _, value := range values {
-            params.Add(key, value)
+
+    base, err := url.Parse(pageURL)
+    if err != nil {
+        return
+    }
+
+    mine := func(js string) {
+        result := jsminer.Mine(js)
+        for _, p := range result.Params {
+            queryParameters.Add(p, "FUZZ")
+            htmlparse.AddSourceTag(sources, p, "js_regex")
+        }
+        if depth >= depthFlag {
+            return
+        }
+        for _, endpoint := range result.Endpoints {
+            resolved, err := base.Parse(endpoint)
+            if err != nil {
+                continue
+            }
+            key := resolved.String()
+            if !inScope(key, seeds, scope, scopeRegex) {
+                continue
+            }
+            normKey, ok := normalizeURL(key)
+            if !ok {
+                continue
+            }
+            if _, loaded := visited.LoadOrStore(normKey, true); loaded {
+                continue
+            }
+            queue.push(workItem{url: key, depth: depth + 1})
         }
     }
+
+    doc.Find("script").Each(func(i int, s *goquery.Selection) {
+        if src, exists := s.Attr("src"); exists {
+            scriptURL, err := base.Parse(src)
+            if err != nil {
+                return
+            }
+            if !jsExternalFlag && scriptURL.Host != base.Host {
+                return
+            }
+            resp, err := client.Get(scriptURL.String())
+            if err != nil {
+                if !quiet {
+                    errorLog.Printf("Error fetching script %s: %v\n", scriptURL, err)
+                }
+                return
+            }
+            defer resp.Body.Close()
+            body, err := io.ReadAll(resp.Body)
+            if err != nil {
+                return
+            }
+            mine(string(body))
+            return
+        }
+        mine(s.Text())
+    })
 }
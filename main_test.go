@@ -0,0 +1,145 @@
+package main
+
+import (
+    "regexp"
+    "testing"
+)
+
+func TestNormalizeURL(t *testing.T) {
+    tests := []struct {
+        name string
+        url  string
+        want string
+    }{
+        {
+            name: "query key order does not matter",
+            url:  "https://example.com/search?b=2&a=1",
+            want: "https://example.com/search?a&b",
+        },
+        {
+            name: "query values do not matter",
+            url:  "https://example.com/search?a=1&b=2",
+            want: "https://example.com/search?a&b",
+        },
+        {
+            name: "no query",
+            url:  "https://example.com/about",
+            want: "https://example.com/about?",
+        },
+    }
+
+    for _, tc := range tests {
+        t.Run(tc.name, func(t *testing.T) {
+            got, ok := normalizeURL(tc.url)
+            if !ok {
+                t.Fatalf("normalizeURL(%q) returned ok=false", tc.url)
+            }
+            if got != tc.want {
+                t.Errorf("normalizeURL(%q) = %q, want %q", tc.url, got, tc.want)
+            }
+        })
+    }
+
+    sameKey, _ := normalizeURL("https://example.com/search?a=1&b=2")
+    sameValue, _ := normalizeURL("https://example.com/search?b=2&a=1")
+    if sameKey != sameValue {
+        t.Errorf("normalizeURL should be order-independent: %q != %q", sameKey, sameValue)
+    }
+}
+
+func TestInScope(t *testing.T) {
+    seeds := []string{"https://example.com/start"}
+    regex := regexp.MustCompile(`^https://.*\.example\.com/`)
+
+    tests := []struct {
+        name       string
+        link       string
+        scope      string
+        scopeRegex *regexp.Regexp
+        want       bool
+    }{
+        {
+            name:  "host scope same host",
+            link:  "https://example.com/page",
+            scope: "host",
+            want:  true,
+        },
+        {
+            name:  "host scope different host",
+            link:  "https://other.com/page",
+            scope: "host",
+            want:  false,
+        },
+        {
+            name:  "host scope different subdomain rejected",
+            link:  "https://api.example.com/page",
+            scope: "host",
+            want:  false,
+        },
+        {
+            name:  "subdomain scope accepts sibling subdomain",
+            link:  "https://api.example.com/page",
+            scope: "subdomain",
+            want:  true,
+        },
+        {
+            name:  "subdomain scope rejects suffix-matching but unrelated host",
+            link:  "https://fooexample.com/page",
+            scope: "subdomain",
+            want:  false,
+        },
+        {
+            name:  "subdomain scope rejects different root domain",
+            link:  "https://example.org/page",
+            scope: "subdomain",
+            want:  false,
+        },
+        {
+            name:       "regex scope matches",
+            link:       "https://api.example.com/page",
+            scope:      "regex",
+            scopeRegex: regex,
+            want:       true,
+        },
+        {
+            name:       "regex scope rejects non-match",
+            link:       "http://example.com/page",
+            scope:      "regex",
+            scopeRegex: regex,
+            want:       false,
+        },
+        {
+            name:  "invalid link URL",
+            link:  ":not a url:",
+            scope: "host",
+            want:  false,
+        },
+    }
+
+    for _, tc := range tests {
+        t.Run(tc.name, func(t *testing.T) {
+            got := inScope(tc.link, seeds, tc.scope, tc.scopeRegex)
+            if got != tc.want {
+                t.Errorf("inScope(%q, scope=%q) = %v, want %v", tc.link, tc.scope, got, tc.want)
+            }
+        })
+    }
+}
+
+func TestRootDomain(t *testing.T) {
+    tests := []struct {
+        host string
+        want string
+    }{
+        {"example.com", "example.com"},
+        {"api.example.com", "example.com"},
+        {"api.staging.example.com", "example.com"},
+        {"localhost", "localhost"},
+    }
+
+    for _, tc := range tests {
+        if got := rootDomain(tc.host); got != tc.want {
+            t.Errorf("rootDomain(%q) = %q, want %q", tc.host, got, tc.want)
+        }
+    }
+}
@@ -0,0 +1,37 @@
+package output
+
+import (
+    "os"
+    "sync"
+)
+
+// burpSink writes a plain list of absolute URLs, one per line, in the
+// format Burp Suite's and ZAP's "paste URLs" site-map/scope importers
+// expect.
+type burpSink struct {
+    mu   sync.Mutex
+    file *os.File
+}
+
+func newBurpSink(path string) (*burpSink, error) {
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    return &burpSink{file: f}, nil
+}
+
+func (s *burpSink) Write(r EndpointResult) error {
+    line, err := withQuery(r)
+    if err != nil {
+        return err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    _, err = s.file.WriteString(line + "\n")
+    return err
+}
+
+func (s *burpSink) Close() error {
+    return s.file.Close()
+}
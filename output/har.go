@@ -0,0 +1,151 @@
+package output
+
+import (
+    "encoding/json"
+    "os"
+    "sync"
+    "time"
+)
+
+// harSink buffers one HAR entry per endpoint that carries a recorded
+// Exchange and writes a single HAR 1.2 log document on Close, so the
+// result can be imported straight into Burp or ZAP and replayed.
+type harSink struct {
+    mu      sync.Mutex
+    file    *os.File
+    entries []harEntry
+}
+
+func newHARSink(path string) (*harSink, error) {
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    return &harSink{file: f}, nil
+}
+
+func (s *harSink) Write(r EndpointResult) error {
+    if r.Exchange == nil {
+        return nil
+    }
+    u, err := withQuery(r)
+    if err != nil {
+        return err
+    }
+
+    e := r.Exchange
+    entry := harEntry{
+        StartedDateTime: e.StartedAt.Format(time.RFC3339Nano),
+        Time:            float64(e.Duration.Milliseconds()),
+        Request: harRequest{
+            Method:      e.Method,
+            URL:         u,
+            HTTPVersion: "HTTP/1.1",
+            Headers:     harHeaders(e.RequestHeaders),
+            QueryString: harQueryString(r.Params),
+        },
+        Response: harResponse{
+            Status:      e.Status,
+            HTTPVersion: "HTTP/1.1",
+            Headers:     harHeaders(e.ResponseHeaders),
+            Content: harContent{
+                Size:     e.BodySize,
+                MimeType: e.ResponseHeaders.Get("Content-Type"),
+                Comment:  "sha256:" + e.BodySHA256,
+            },
+        },
+        Timings: harTimings{Send: 0, Wait: float64(e.Duration.Milliseconds()), Receive: 0},
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.entries = append(s.entries, entry)
+    return nil
+}
+
+func (s *harSink) Close() error {
+    defer s.file.Close()
+    doc := harDocument{Log: harLog{
+        Version: "1.2",
+        Creator: harCreator{Name: "arjunx", Version: "1"},
+        Entries: s.entries,
+    }}
+    enc := json.NewEncoder(s.file)
+    enc.SetIndent("", "  ")
+    return enc.Encode(doc)
+}
+
+type harDocument struct {
+    Log harLog `json:"log"`
+}
+
+type harLog struct {
+    Version string     `json:"version"`
+    Creator harCreator `json:"creator"`
+    Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+    Name    string `json:"name"`
+    Version string `json:"version"`
+}
+
+type harEntry struct {
+    StartedDateTime string      `json:"startedDateTime"`
+    Time            float64     `json:"time"`
+    Request         harRequest  `json:"request"`
+    Response        harResponse `json:"response"`
+    Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+    Method      string        `json:"method"`
+    URL         string        `json:"url"`
+    HTTPVersion string        `json:"httpVersion"`
+    Headers     []harNameValue `json:"headers"`
+    QueryString []harNameValue `json:"queryString"`
+}
+
+type harResponse struct {
+    Status      int           `json:"status"`
+    HTTPVersion string        `json:"httpVersion"`
+    Headers     []harNameValue `json:"headers"`
+    Content     harContent    `json:"content"`
+}
+
+type harContent struct {
+    Size     int    `json:"size"`
+    MimeType string `json:"mimeType"`
+    Comment  string `json:"comment"`
+}
+
+type harNameValue struct {
+    Name  string `json:"name"`
+    Value string `json:"value"`
+}
+
+type harTimings struct {
+    Send    float64 `json:"send"`
+    Wait    float64 `json:"wait"`
+    Receive float64 `json:"receive"`
+}
+
+func harHeaders(h map[string][]string) []harNameValue {
+    var out []harNameValue
+    for name, values := range h {
+        for _, v := range values {
+            out = append(out, harNameValue{Name: name, Value: v})
+        }
+    }
+    return out
+}
+
+func harQueryString(params map[string][]string) []harNameValue {
+    var out []harNameValue
+    for name, values := range params {
+        for _, v := range values {
+            out = append(out, harNameValue{Name: name, Value: v})
+        }
+    }
+    return out
+}
@@ -0,0 +1,54 @@
+package output
+
+import (
+    "encoding/json"
+    "os"
+    "sort"
+    "sync"
+)
+
+// jsonSink buffers every endpoint and writes a single JSON array document
+// on Close, so tools that expect one complete JSON value (rather than
+// newline-delimited objects, see jsonlSink) can consume it directly.
+type jsonSink struct {
+    mu      sync.Mutex
+    file    *os.File
+    results []jsonlRecord
+}
+
+func newJSONSink(path string) (*jsonSink, error) {
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    return &jsonSink{file: f}, nil
+}
+
+func (s *jsonSink) Write(r EndpointResult) error {
+    names := make([]string, 0, len(r.Params))
+    for name := range r.Params {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    record := jsonlRecord{URL: r.URL}
+    for _, name := range names {
+        record.Params = append(record.Params, jsonlParamEntry{
+            Name:    name,
+            Values:  r.Params[name],
+            Sources: r.Sources[name],
+        })
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.results = append(s.results, record)
+    return nil
+}
+
+func (s *jsonSink) Close() error {
+    defer s.file.Close()
+    enc := json.NewEncoder(s.file)
+    enc.SetIndent("", "  ")
+    return enc.Encode(s.results)
+}
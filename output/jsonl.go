@@ -0,0 +1,68 @@
+package output
+
+import (
+    "encoding/json"
+    "os"
+    "sort"
+    "sync"
+)
+
+// jsonlRecord is one line of jsonl output: an endpoint and, for each
+// discovered parameter, the values seen and where it was found.
+type jsonlRecord struct {
+    URL    string           `json:"url"`
+    Params []jsonlParamEntry `json:"params"`
+}
+
+type jsonlParamEntry struct {
+    Name    string   `json:"name"`
+    Values  []string `json:"values"`
+    Sources []string `json:"sources"`
+}
+
+// jsonlSink emits one JSON object per endpoint, newline-delimited, with
+// each parameter's values and discovery sources (html_input,
+// html_form_action, js_regex, xhr).
+type jsonlSink struct {
+    mu   sync.Mutex
+    file *os.File
+}
+
+func newJSONLSink(path string) (*jsonlSink, error) {
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    return &jsonlSink{file: f}, nil
+}
+
+func (s *jsonlSink) Write(r EndpointResult) error {
+    names := make([]string, 0, len(r.Params))
+    for name := range r.Params {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    record := jsonlRecord{URL: r.URL}
+    for _, name := range names {
+        record.Params = append(record.Params, jsonlParamEntry{
+            Name:    name,
+            Values:  r.Params[name],
+            Sources: r.Sources[name],
+        })
+    }
+
+    line, err := json.Marshal(record)
+    if err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    _, err = s.file.Write(append(line, '\n'))
+    return err
+}
+
+func (s *jsonlSink) Close() error {
+    return s.file.Close()
+}
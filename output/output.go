@@ -0,0 +1,77 @@
+// Package output defines the pluggable sinks arjunx writes discovered
+// parameters to. Each sink owns its own write coordination (buffering,
+// locking, final serialization), so callers just call Write once per
+// endpoint and Close when the run is done.
+package output
+
+import (
+    "fmt"
+    "net/http"
+    "net/url"
+    "time"
+)
+
+// Exchange is the HTTP request/response pair that produced an
+// EndpointResult, kept around for sinks (currently only har) that need
+// more than the merged parameter set.
+type Exchange struct {
+    Method          string
+    Status          int
+    RequestHeaders  http.Header
+    ResponseHeaders http.Header
+    StartedAt       time.Time
+    Duration        time.Duration
+    BodySize        int
+    BodySHA256      string
+}
+
+// EndpointResult is one endpoint's merged output: its URL (query string
+// stripped), the parameters discovered for it, and where each parameter
+// name was discovered from (html_input, html_form_action, js_regex, xhr).
+type EndpointResult struct {
+    URL     string
+    Params  url.Values
+    Sources map[string][]string
+    Exchange *Exchange
+}
+
+// Sink receives one EndpointResult per endpoint and owns turning it into
+// whatever the selected -of format requires. Close flushes and finalizes
+// any buffered output (the json and har sinks must emit a single document,
+// so they can't write incrementally).
+type Sink interface {
+    Write(EndpointResult) error
+    Close() error
+}
+
+// New builds the sink registered under format, writing to path. Supported
+// formats: txt (default, plain URLs with merged query strings), json (one
+// JSON array document), jsonl (one JSON object per line, with param
+// sources), burp (plain URL list for Burp/ZAP "paste URLs" import), and har
+// (HAR 1.2, replayable in Burp/ZAP).
+func New(format, path string) (Sink, error) {
+    switch format {
+    case "", "txt":
+        return newTxtSink(path)
+    case "json":
+        return newJSONSink(path)
+    case "jsonl":
+        return newJSONLSink(path)
+    case "burp":
+        return newBurpSink(path)
+    case "har":
+        return newHARSink(path)
+    default:
+        return nil, fmt.Errorf("unknown output format %q", format)
+    }
+}
+
+// withQuery returns r.URL with its query string set from r.Params.
+func withQuery(r EndpointResult) (string, error) {
+    u, err := url.Parse(r.URL)
+    if err != nil {
+        return "", fmt.Errorf("error parsing URL %s: %v", r.URL, err)
+    }
+    u.RawQuery = r.Params.Encode()
+    return u.String(), nil
+}
@@ -0,0 +1,36 @@
+package output
+
+import (
+    "os"
+    "sync"
+)
+
+// txtSink writes one URL per line, its query string set to the merged
+// parameters for that endpoint. This is the original arjunx output format.
+type txtSink struct {
+    mu   sync.Mutex
+    file *os.File
+}
+
+func newTxtSink(path string) (*txtSink, error) {
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    return &txtSink{file: f}, nil
+}
+
+func (s *txtSink) Write(r EndpointResult) error {
+    line, err := withQuery(r)
+    if err != nil {
+        return err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    _, err = s.file.WriteString(line + "\n")
+    return err
+}
+
+func (s *txtSink) Close() error {
+    return s.file.Close()
+}
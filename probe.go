@@ -0,0 +1,208 @@
+package main
+
+import (
+    "crypto/rand"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "sync"
+)
+
+// probeResult is one line of -json output: the signals observed for a
+// single parameter on a single endpoint.
+type probeResult struct {
+    URL     string   `json:"url"`
+    Param   string   `json:"param"`
+    Signals []string `json:"signals"`
+}
+
+// jsonSink serializes probeResults to a file as JSON lines, one object per
+// line. Workers write to it concurrently as they finish probing, so writes
+// are serialized with a mutex.
+type jsonSink struct {
+    mu   sync.Mutex
+    file *os.File
+}
+
+func newJSONSink(path string) (*jsonSink, error) {
+    f, err := os.Create(path)
+    if err != nil {
+        return nil, err
+    }
+    return &jsonSink{file: f}, nil
+}
+
+func (s *jsonSink) write(r probeResult) error {
+    line, err := json.Marshal(r)
+    if err != nil {
+        return err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    _, err = s.file.Write(append(line, '\n'))
+    return err
+}
+
+func (s *jsonSink) Close() error {
+    return s.file.Close()
+}
+
+// canaryToken returns a short random token that is astronomically unlikely
+// to appear in a response by coincidence, so any appearance of it in the
+// probed response can be attributed to the parameter under test.
+func canaryToken() string {
+    buf := make([]byte, 6)
+    if _, err := rand.Read(buf); err != nil {
+        return "axprobedeadbeef"
+    }
+    return fmt.Sprintf("axprobe%x", buf)
+}
+
+// probeParams issues a canary-free baseline request carrying the full
+// extracted parameter set, then one follow-up request per parameter,
+// substituting a unique canary token for that parameter's value while
+// leaving the others as already extracted. Each parameter is classified by
+// how its response differs from the baseline (status, body length,
+// headers), so the diff isolates that parameter's effect rather than the
+// difference between "no params" and "all params". Both the baseline and
+// probe requests use a redirect-disabled client so a 3xx response and its
+// Location header survive for the reflect_redirect/status_changed signals.
+func probeParams(baseClient *http.Client, method, finalURL string, customHeaders headerFlag, params url.Values, lenDeltaThreshold int, errorLog *log.Logger, quiet bool) []probeResult {
+    client := &http.Client{
+        Transport: baseClient.Transport,
+        Timeout:   baseClient.Timeout,
+        CheckRedirect: func(req *http.Request, via []*http.Request) error {
+            return http.ErrUseLastResponse
+        },
+    }
+
+    baselineValues := cloneValues(params)
+    baselineURL, err := url.Parse(finalURL)
+    if err != nil {
+        return nil
+    }
+    baselineURL.RawQuery = baselineValues.Encode()
+
+    baselineResp, err := doRequest(client, method, baselineURL.String(), customHeaders)
+    if err != nil {
+        if !quiet {
+            errorLog.Printf("Error probing baseline for %s: %v\n", finalURL, err)
+        }
+        return nil
+    }
+    baselineBody, err := readAndClose(baselineResp)
+    if err != nil {
+        if !quiet {
+            errorLog.Printf("Error reading probe baseline for %s: %v\n", finalURL, err)
+        }
+        return nil
+    }
+    baselineStatus := baselineResp.StatusCode
+    baselineLen := len(baselineBody)
+
+    var results []probeResult
+
+    for param := range params {
+        token := canaryToken()
+
+        probeURL, err := url.Parse(finalURL)
+        if err != nil {
+            continue
+        }
+        substituted := cloneValues(params)
+        substituted.Set(param, token)
+        probeURL.RawQuery = substituted.Encode()
+
+        resp, err := doRequest(client, method, probeURL.String(), customHeaders)
+        if err != nil {
+            if !quiet {
+                errorLog.Printf("Error probing param %s on %s: %v\n", param, finalURL, err)
+            }
+            continue
+        }
+
+        var signals []string
+
+        if loc := resp.Header.Get("Location"); loc != "" && strings.Contains(loc, token) {
+            signals = append(signals, "reflect_redirect")
+        }
+        for name, values := range resp.Header {
+            if name == "Location" {
+                continue
+            }
+            for _, value := range values {
+                if strings.Contains(value, token) {
+                    signals = append(signals, "reflect_header:"+name)
+                    break
+                }
+            }
+        }
+
+        body, err := readAndClose(resp)
+        if err != nil {
+            if !quiet {
+                errorLog.Printf("Error reading probe response for param %s on %s: %v\n", param, finalURL, err)
+            }
+            continue
+        }
+
+        if strings.Contains(body, token) {
+            signals = append(signals, "reflect_body")
+        }
+        if delta := len(body) - baselineLen; delta > lenDeltaThreshold || -delta > lenDeltaThreshold {
+            signals = append(signals, fmt.Sprintf("len_delta:%+d", delta))
+        }
+        if resp.StatusCode != baselineStatus {
+            signals = append(signals, fmt.Sprintf("status_changed:%d->%d", baselineStatus, resp.StatusCode))
+        }
+
+        if len(signals) > 0 {
+            results = append(results, probeResult{URL: finalURL, Param: param, Signals: signals})
+        }
+    }
+
+    return results
+}
+
+// doRequest issues a GET/method request against rawurl with customHeaders
+// applied, the way both the baseline and each per-parameter probe request
+// are built.
+func doRequest(client *http.Client, method, rawurl string, customHeaders headerFlag) (*http.Response, error) {
+    req, err := http.NewRequest(method, rawurl, nil)
+    if err != nil {
+        return nil, err
+    }
+    for _, header := range customHeaders {
+        parts := strings.SplitN(header, ":", 2)
+        if len(parts) == 2 {
+            req.Header.Add(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+        }
+    }
+    return client.Do(req)
+}
+
+// cloneValues returns a new url.Values holding only the first value of each
+// key in v (FUZZ where a key has no value), so probing one parameter doesn't
+// mutate the shared query parameters used for the next.
+func cloneValues(v url.Values) url.Values {
+    clone := make(url.Values, len(v))
+    for key, values := range v {
+        if len(values) > 0 {
+            clone.Set(key, values[0])
+        } else {
+            clone.Set(key, "FUZZ")
+        }
+    }
+    return clone
+}
+
+func readAndClose(resp *http.Response) (string, error) {
+    defer resp.Body.Close()
+    body, err := io.ReadAll(resp.Body)
+    return string(body), err
+}
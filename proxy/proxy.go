@@ -0,0 +1,347 @@
+// Package proxy implements arjunx's passive discovery mode: a local
+// intercepting HTTP/HTTPS proxy that harvests parameters from whatever
+// traffic is routed through it, instead of arjunx driving the requests
+// itself.
+package proxy
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/tls"
+    "crypto/x509"
+    "crypto/x509/pkix"
+    "encoding/json"
+    "encoding/pem"
+    "flag"
+    "fmt"
+    "io"
+    "log"
+    "math/big"
+    "mime"
+    "net/http"
+    "net/url"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+
+    "github.com/elazarl/goproxy"
+
+    "github.com/djallalzoldik/arjunx/htmlparse"
+    "github.com/djallalzoldik/arjunx/jsminer"
+    "github.com/djallalzoldik/arjunx/output"
+)
+
+// Run parses args as the proxy subcommand's own flags and starts listening.
+// It blocks until the process receives SIGINT/SIGTERM, flushing the
+// harvested parameter dictionary before returning.
+func Run(args []string) error {
+    fs := flag.NewFlagSet("proxy", flag.ExitOnError)
+    addr := fs.String("addr", "127.0.0.1:8081", "Address for the intercepting proxy to listen on")
+    caDir := fs.String("ca-dir", "", "Directory to read/write the proxy's root CA cert and key (default: an in-memory CA for this run only)")
+    outputFileFlag := fs.String("o", "extracted_params.txt", "Output file for harvested parameters")
+    outputFormatFlag := fs.String("of", "txt", "Output format: txt, json, jsonl, burp, or har")
+    verbose := fs.Bool("v", false, "Log every intercepted request")
+    if err := fs.Parse(args); err != nil {
+        return err
+    }
+
+    ca, err := loadOrCreateCA(*caDir)
+    if err != nil {
+        return fmt.Errorf("setting up CA: %v", err)
+    }
+    goproxy.GoproxyCa = ca
+
+    sink, err := output.New(*outputFormatFlag, *outputFileFlag)
+    if err != nil {
+        return fmt.Errorf("creating output sink: %v", err)
+    }
+
+    h := newHarvester()
+
+    p := goproxy.NewProxyHttpServer()
+    p.Verbose = *verbose
+    p.OnRequest().HandleConnect(goproxy.AlwaysMitm)
+    p.OnRequest().DoFunc(func(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+        h.observeRequest(req)
+        return req, nil
+    })
+    p.OnResponse().DoFunc(func(resp *http.Response, ctx *goproxy.ProxyCtx) *http.Response {
+        if resp != nil {
+            h.observeResponse(resp)
+        }
+        return resp
+    })
+
+    server := &http.Server{Addr: *addr, Handler: p}
+
+    sigCh := make(chan os.Signal, 1)
+    signal.Notify(sigCh, syscall.SIGUSR1, os.Interrupt, syscall.SIGTERM)
+
+    serveErr := make(chan error, 1)
+    go func() {
+        serveErr <- server.ListenAndServe()
+    }()
+
+    log.Printf("proxy: listening on %s (CA dir: %s)", *addr, *caDir)
+
+    for {
+        select {
+        case sig := <-sigCh:
+            if sig == syscall.SIGUSR1 {
+                log.Printf("proxy: SIGUSR1 received, flushing %d host(s)", h.count())
+                if err := h.flush(sink); err != nil {
+                    log.Printf("proxy: error flushing: %v", err)
+                }
+                continue
+            }
+            log.Printf("proxy: shutting down")
+            ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+            defer cancel()
+            server.Shutdown(ctx)
+            return finalFlush(h, sink)
+        case err := <-serveErr:
+            if err != nil && err != http.ErrServerClosed {
+                sink.Close()
+                return err
+            }
+            return finalFlush(h, sink)
+        }
+    }
+}
+
+// finalFlush writes the harvester's remaining parameters to sink and closes
+// it. Close must only be called once: the json and har sinks buffer every
+// record and only serialize on Close, so SIGUSR1's periodic h.flush (which
+// never closes) is what lets a run be flushed more than once, while this is
+// reserved for the one true exit path, where it emits everything written
+// across every earlier flush plus whatever the harvester still holds.
+func finalFlush(h *harvester, sink output.Sink) error {
+    flushErr := h.flush(sink)
+    closeErr := sink.Close()
+    if flushErr != nil {
+        return flushErr
+    }
+    return closeErr
+}
+
+// loadOrCreateCA loads a CA keypair from dir, generating and persisting a
+// fresh one on first run. An empty dir generates a throwaway CA that lives
+// only for this process.
+func loadOrCreateCA(dir string) (tls.Certificate, error) {
+    if dir == "" {
+        return generateCA()
+    }
+    if err := os.MkdirAll(dir, 0700); err != nil {
+        return tls.Certificate{}, err
+    }
+
+    certPath := filepath.Join(dir, "arjunx-ca-cert.pem")
+    keyPath := filepath.Join(dir, "arjunx-ca-key.pem")
+
+    if _, err := os.Stat(certPath); err == nil {
+        return tls.LoadX509KeyPair(certPath, keyPath)
+    }
+
+    cert, certPEM, keyPEM, err := generateCAPEM()
+    if err != nil {
+        return tls.Certificate{}, err
+    }
+    if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+        return tls.Certificate{}, err
+    }
+    if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+        return tls.Certificate{}, err
+    }
+    return cert, nil
+}
+
+func generateCA() (tls.Certificate, error) {
+    cert, _, _, err := generateCAPEM()
+    return cert, err
+}
+
+// generateCAPEM creates a self-signed root CA suitable for MITM-ing TLS
+// connections, returning it both as a parsed tls.Certificate and as the raw
+// PEM bytes loadOrCreateCA persists to disk.
+func generateCAPEM() (tls.Certificate, []byte, []byte, error) {
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        return tls.Certificate{}, nil, nil, err
+    }
+
+    serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+    if err != nil {
+        return tls.Certificate{}, nil, nil, err
+    }
+
+    template := &x509.Certificate{
+        SerialNumber:          serial,
+        Subject:               pkix.Name{Organization: []string{"arjunx"}, CommonName: "arjunx MITM CA"},
+        NotBefore:             time.Now().Add(-time.Hour),
+        NotAfter:              time.Now().AddDate(10, 0, 0),
+        IsCA:                  true,
+        KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+        BasicConstraintsValid: true,
+    }
+
+    der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+    if err != nil {
+        return tls.Certificate{}, nil, nil, err
+    }
+
+    certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+    keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+    cert, err := tls.X509KeyPair(certPEM, keyPEM)
+    if err != nil {
+        return tls.Certificate{}, nil, nil, err
+    }
+    leaf, err := x509.ParseCertificate(der)
+    if err != nil {
+        return tls.Certificate{}, nil, nil, err
+    }
+    cert.Leaf = leaf
+    return cert, certPEM, keyPEM, nil
+}
+
+// harvester aggregates parameters per host as traffic passes through the
+// proxy, independent of which request or response turned each one up.
+type harvester struct {
+    mu   sync.Mutex
+    byHost map[string]*output.EndpointResult
+}
+
+func newHarvester() *harvester {
+    return &harvester{byHost: make(map[string]*output.EndpointResult)}
+}
+
+func (h *harvester) count() int {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    return len(h.byHost)
+}
+
+func (h *harvester) record(host string, params url.Values, sources map[string][]string) {
+    if len(params) == 0 {
+        return
+    }
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    r, ok := h.byHost[host]
+    if !ok {
+        r = &output.EndpointResult{URL: "http://" + host + "/", Params: make(url.Values), Sources: make(map[string][]string)}
+        h.byHost[host] = r
+    }
+    htmlparse.MergeValues(r.Params, params)
+    htmlparse.MergeSources(r.Sources, sources)
+}
+
+// observeRequest records a request's URL query parameters and, for
+// form-encoded or JSON POST bodies, its body keys.
+func (h *harvester) observeRequest(req *http.Request) {
+    params := make(url.Values)
+    sources := make(map[string][]string)
+
+    for key, values := range req.URL.Query() {
+        for _, v := range values {
+            params.Add(key, v)
+        }
+        htmlparse.AddSourceTag(sources, key, "xhr")
+    }
+
+    if req.Method == http.MethodPost && req.Body != nil {
+        body, err := io.ReadAll(req.Body)
+        req.Body.Close()
+        req.Body = io.NopCloser(bytes.NewReader(body))
+        if err == nil {
+            mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+            switch mediaType {
+            case "application/x-www-form-urlencoded":
+                if form, err := url.ParseQuery(string(body)); err == nil {
+                    for key, values := range form {
+                        for _, v := range values {
+                            params.Add(key, v)
+                        }
+                        htmlparse.AddSourceTag(sources, key, "xhr")
+                    }
+                }
+            case "application/json":
+                for _, key := range jsonTopLevelKeys(body) {
+                    params.Add(key, "FUZZ")
+                    htmlparse.AddSourceTag(sources, key, "xhr")
+                }
+            }
+        }
+    }
+
+    h.record(req.URL.Host, params, sources)
+}
+
+// observeResponse mines HTML and JS response bodies for parameters using
+// the same pipeline arjunx's active crawler uses, then restores the body so
+// the client still receives it unmodified.
+func (h *harvester) observeResponse(resp *http.Response) {
+    if resp.Request == nil || resp.Body == nil {
+        return
+    }
+    body, err := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    resp.Body = io.NopCloser(bytes.NewReader(body))
+    if err != nil {
+        return
+    }
+
+    contentType := resp.Header.Get("Content-Type")
+    host := resp.Request.URL.Host
+
+    switch {
+    case strings.Contains(contentType, "html"):
+        params, sources := htmlparse.ExtractQueryParams(string(body))
+        h.record(host, params, sources)
+    case strings.Contains(contentType, "javascript") || strings.HasSuffix(resp.Request.URL.Path, ".js"):
+        mined := jsminer.Mine(string(body))
+        params := make(url.Values)
+        sources := make(map[string][]string)
+        for _, name := range mined.Params {
+            params.Add(name, "FUZZ")
+            htmlparse.AddSourceTag(sources, name, "js_regex")
+        }
+        h.record(host, params, sources)
+    }
+}
+
+// flush writes every host's aggregated parameter dictionary to sink and
+// resets the harvester so a later flush doesn't repeat already-written data.
+func (h *harvester) flush(sink output.Sink) error {
+    h.mu.Lock()
+    results := h.byHost
+    h.byHost = make(map[string]*output.EndpointResult)
+    h.mu.Unlock()
+
+    for _, r := range results {
+        if err := sink.Write(*r); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// jsonTopLevelKeys returns the top-level keys of a JSON object body, or nil
+// if body isn't a JSON object.
+func jsonTopLevelKeys(body []byte) []string {
+    var obj map[string]json.RawMessage
+    if err := json.Unmarshal(body, &obj); err != nil {
+        return nil
+    }
+    keys := make([]string, 0, len(obj))
+    for key := range obj {
+        keys = append(keys, key)
+    }
+    return keys
+}
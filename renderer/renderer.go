@@ -0,0 +1,97 @@
+// Package renderer drives a headless Chromium instance over the Chrome
+// DevTools Protocol so arjunx can see pages the same way a browser does:
+// after JS execution, with XHR/fetch activity observed along the way.
+package renderer
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/chromedp/cdproto/network"
+    "github.com/chromedp/chromedp"
+)
+
+// Result holds what was observed while rendering a single page: the DOM
+// serialized after JS execution, and every URL seen on the Network domain
+// that carries a query string (XHR/fetch requests, primarily).
+type Result struct {
+    HTML        string
+    RequestURLs []string
+}
+
+// Render navigates to targetURL in a headless Chromium tab, waits for the
+// network to go idle, and returns the post-JS DOM along with every request
+// URL carrying a query string captured over CDP. idleWait is the quiet period (no new
+// network activity) required before the page is considered settled.
+func Render(ctx context.Context, targetURL string, timeout, idleWait time.Duration) (*Result, error) {
+    allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, chromedp.DefaultExecAllocatorOptions[:]...)
+    defer cancelAlloc()
+
+    taskCtx, cancelTask := chromedp.NewContext(allocCtx)
+    defer cancelTask()
+
+    taskCtx, cancelTimeout := context.WithTimeout(taskCtx, timeout)
+    defer cancelTimeout()
+
+    var (
+        mu          sync.Mutex
+        requestURLs []string
+        seen        = make(map[string]bool)
+        lastActivity = time.Now()
+    )
+
+    chromedp.ListenTarget(taskCtx, func(ev interface{}) {
+        switch e := ev.(type) {
+        case *network.EventRequestWillBeSent:
+            mu.Lock()
+            lastActivity = time.Now()
+            u := e.Request.URL
+            if strings.Contains(u, "?") && !seen[u] {
+                seen[u] = true
+                requestURLs = append(requestURLs, u)
+            }
+            mu.Unlock()
+        }
+    })
+
+    var html string
+    err := chromedp.Run(taskCtx,
+        network.Enable(),
+        chromedp.Navigate(targetURL),
+        waitNetworkIdle(&mu, &lastActivity, idleWait),
+        chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("rendering %s: %v", targetURL, err)
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+    return &Result{HTML: html, RequestURLs: requestURLs}, nil
+}
+
+// waitNetworkIdle polls until no new request has been observed for idleWait,
+// approximating the "networkidle" condition without pulling in a full
+// lifecycle-event dependency.
+func waitNetworkIdle(mu *sync.Mutex, lastActivity *time.Time, idleWait time.Duration) chromedp.ActionFunc {
+    return func(ctx context.Context) error {
+        ticker := time.NewTicker(100 * time.Millisecond)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ctx.Done():
+                return ctx.Err()
+            case <-ticker.C:
+                mu.Lock()
+                quiet := time.Since(*lastActivity)
+                mu.Unlock()
+                if quiet >= idleWait {
+                    return nil
+                }
+            }
+        }
+    }
+}